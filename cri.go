@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultCRIMaxBufferBytes bounds how much a single partial CRI record may
+// grow to before it is dropped, protecting against unbounded memory growth
+// on a runaway partial stream that never sees a terminating "F" line.
+const defaultCRIMaxBufferBytes = 1024 * 1024 // 1MiB
+
+type criTag byte
+
+const (
+	criTagPartial criTag = 'P'
+	criTagFull    criTag = 'F'
+)
+
+// criLine is a single line from a CRI-formatted log file, as written by
+// containerd/CRI-O: "<RFC3339Nano timestamp> <stdout|stderr> <P|F> <message>".
+type criLine struct {
+	Timestamp string
+	Stream    string
+	Tag       criTag
+	Message   string
+}
+
+// parseCRILine splits a raw log line into its CRI fields.
+func parseCRILine(record string) (criLine, error) {
+	parts := strings.SplitN(record, " ", 4)
+	if len(parts) != 4 {
+		return criLine{}, fmt.Errorf("CRI Parse Error: expected 4 space-separated fields, got %d", len(parts))
+	}
+	if len(parts[2]) != 1 {
+		return criLine{}, fmt.Errorf("CRI Parse Error: unexpected tag %q", parts[2])
+	}
+	tag := criTag(parts[2][0])
+	if tag != criTagPartial && tag != criTagFull {
+		return criLine{}, fmt.Errorf("CRI Parse Error: unknown tag %q", parts[2])
+	}
+	return criLine{
+		Timestamp: parts[0],
+		Stream:    parts[1],
+		Tag:       tag,
+		Message:   parts[3],
+	}, nil
+}
+
+// criReassembler buffers partial CRI log lines per stream, since stdout and
+// stderr can interleave within the same file, until a terminating "F" line
+// arrives and the concatenated payload can be emitted as one record.
+type criReassembler struct {
+	maxBufferBytes int
+	buffers        map[string]*strings.Builder
+	// onDrop, if set, is invoked whenever a buffered record is dropped for
+	// exceeding maxBufferBytes, so callers can surface the event as a metric.
+	onDrop func()
+}
+
+func newCRIReassembler(maxBufferBytes int) *criReassembler {
+	if maxBufferBytes <= 0 {
+		maxBufferBytes = defaultCRIMaxBufferBytes
+	}
+	return &criReassembler{
+		maxBufferBytes: maxBufferBytes,
+		buffers:        make(map[string]*strings.Builder),
+	}
+}
+
+// Feed appends a parsed CRI line to the reassembler for its stream. It
+// returns the completed message and true once a full ("F") record is
+// available; a partial ("P") record returns ("", false) while it continues
+// to accumulate in the buffer.
+func (r *criReassembler) Feed(l criLine) (string, bool) {
+	buf, ok := r.buffers[l.Stream]
+	if !ok {
+		buf = &strings.Builder{}
+		r.buffers[l.Stream] = buf
+	}
+
+	// The size guard only protects against a runaway partial reassembly; a complete
+	// line with nothing already buffered isn't one, however long it is on its own.
+	reassembling := buf.Len() > 0 || l.Tag == criTagPartial
+	if reassembling && buf.Len()+len(l.Message) > r.maxBufferBytes {
+		fmt.Printf("Warning: CRI reassembly buffer for stream %q exceeded %d bytes, dropping buffered record\n", l.Stream, r.maxBufferBytes)
+		buf.Reset()
+		if r.onDrop != nil {
+			r.onDrop()
+		}
+		return "", false
+	}
+
+	buf.WriteString(l.Message)
+
+	if l.Tag == criTagFull {
+		message := buf.String()
+		buf.Reset()
+		return message, true
+	}
+	return "", false
+}
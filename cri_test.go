@@ -0,0 +1,134 @@
+package main
+
+import "testing"
+
+func TestParseCRILine(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  string
+		want    criLine
+		wantErr bool
+	}{
+		{
+			name:   "full stdout line",
+			record: "2026-07-29T12:00:00.000000000Z stdout F hello world",
+			want: criLine{
+				Timestamp: "2026-07-29T12:00:00.000000000Z",
+				Stream:    "stdout",
+				Tag:       criTagFull,
+				Message:   "hello world",
+			},
+		},
+		{
+			name:   "partial stderr chunk",
+			record: "2026-07-29T12:00:00.000000000Z stderr P chunk one",
+			want: criLine{
+				Timestamp: "2026-07-29T12:00:00.000000000Z",
+				Stream:    "stderr",
+				Tag:       criTagPartial,
+				Message:   "chunk one",
+			},
+		},
+		{
+			name:    "too few fields",
+			record:  "2026-07-29T12:00:00.000000000Z stdout F",
+			wantErr: true,
+		},
+		{
+			name:    "unknown tag",
+			record:  "2026-07-29T12:00:00.000000000Z stdout X hello",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseCRILine(tc.record)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseCRILine(%q) = %+v, nil; want error", tc.record, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCRILine(%q) returned unexpected error: %v", tc.record, err)
+			}
+			if got != tc.want {
+				t.Fatalf("parseCRILine(%q) = %+v; want %+v", tc.record, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCRIReassemblerFeed(t *testing.T) {
+	r := newCRIReassembler(1024)
+
+	if msg, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagPartial, Message: "hello "}); ok {
+		t.Fatalf("partial chunk reported complete, got message %q", msg)
+	}
+	msg, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagFull, Message: "world"})
+	if !ok {
+		t.Fatalf("final chunk did not complete the record")
+	}
+	if msg != "hello world" {
+		t.Fatalf("reassembled message = %q; want %q", msg, "hello world")
+	}
+}
+
+func TestCRIReassemblerFeedInterleavedStreams(t *testing.T) {
+	r := newCRIReassembler(1024)
+
+	if _, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagPartial, Message: "out-1 "}); ok {
+		t.Fatalf("stdout partial reported complete early")
+	}
+	if _, ok := r.Feed(criLine{Stream: "stderr", Tag: criTagPartial, Message: "err-1 "}); ok {
+		t.Fatalf("stderr partial reported complete early")
+	}
+
+	errMsg, ok := r.Feed(criLine{Stream: "stderr", Tag: criTagFull, Message: "err-2"})
+	if !ok || errMsg != "err-1 err-2" {
+		t.Fatalf("stderr reassembly = (%q, %v); want (\"err-1 err-2\", true)", errMsg, ok)
+	}
+
+	outMsg, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagFull, Message: "out-2"})
+	if !ok || outMsg != "out-1 out-2" {
+		t.Fatalf("stdout reassembly = (%q, %v); want (\"out-1 out-2\", true)", outMsg, ok)
+	}
+}
+
+func TestCRIReassemblerFeedDropsOversizeBuffer(t *testing.T) {
+	r := newCRIReassembler(10)
+	dropped := 0
+	r.onDrop = func() { dropped++ }
+
+	if _, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagPartial, Message: "0123456789"}); ok {
+		t.Fatalf("first chunk unexpectedly completed")
+	}
+	if _, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagFull, Message: "overflow"}); ok {
+		t.Fatalf("record exceeding maxBufferBytes should be dropped, not completed")
+	}
+	if dropped != 1 {
+		t.Fatalf("onDrop called %d times; want 1", dropped)
+	}
+
+	// the buffer should have been reset, so a fresh record reassembles normally.
+	msg, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagFull, Message: "fresh"})
+	if !ok || msg != "fresh" {
+		t.Fatalf("reassembly after drop = (%q, %v); want (\"fresh\", true)", msg, ok)
+	}
+}
+
+func TestCRIReassemblerFeedAllowsOversizeStandaloneFullLine(t *testing.T) {
+	r := newCRIReassembler(20)
+	dropped := 0
+	r.onDrop = func() { dropped++ }
+
+	message := "this single F-tagged line is well over twenty bytes long"
+	msg, ok := r.Feed(criLine{Stream: "stdout", Tag: criTagFull, Message: message})
+	if !ok || msg != message {
+		t.Fatalf("Feed(standalone oversize F line) = (%q, %v); want (%q, true)", msg, ok, message)
+	}
+	if dropped != 0 {
+		t.Fatalf("onDrop called %d times for a non-reassembled line; want 0", dropped)
+	}
+}
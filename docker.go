@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultDockerPartialTimeout bounds how long a partial-message group may
+// wait for its Last chunk before being flushed as-is, so a truncated
+// sequence (e.g. the container was killed mid-write) isn't held forever.
+const defaultDockerPartialTimeout = 5 * time.Second
+
+// PartialLogMetaData mirrors the json-file driver's partial_log_metadata
+// field, emitted when a single log line exceeds the daemon's 16K buffer and
+// has to be split across multiple JSON records.
+type PartialLogMetaData struct {
+	Last    bool   `json:"last"`
+	ID      string `json:"id"`
+	Ordinal int    `json:"ordinal"`
+}
+
+type dockerChunk struct {
+	Ordinal int
+	Log     string
+}
+
+type dockerPending struct {
+	chunks    []dockerChunk
+	firstSeen time.Time
+	stream    string
+	time      string
+}
+
+// expiredDockerGroup is the best-effort payload returned by ExpireStale for
+// a partial-message group that timed out before its Last chunk arrived.
+type expiredDockerGroup struct {
+	Text   string
+	Stream string
+	Time   string
+}
+
+// dockerReassembler accumulates Docker json-file partial-message chunks,
+// keyed by PartialLogMetaData.ID, and merges them in Ordinal order once the
+// chunk tagged Last arrives (or the group times out).
+type dockerReassembler struct {
+	timeout time.Duration
+	pending map[string]*dockerPending
+}
+
+func newDockerReassembler(timeout time.Duration) *dockerReassembler {
+	if timeout <= 0 {
+		timeout = defaultDockerPartialTimeout
+	}
+	return &dockerReassembler{
+		timeout: timeout,
+		pending: make(map[string]*dockerPending),
+	}
+}
+
+// Feed adds a record to the reassembler. Records with no partial metadata
+// are complete already and are returned unchanged. Partial chunks are
+// buffered by ID until the chunk tagged Last arrives, at which point the
+// ordered concatenation of their Log fields is returned.
+func (r *dockerReassembler) Feed(rec *DockerJSONLogRecord) (string, bool) {
+	if rec.Partial == nil {
+		return rec.Log, true
+	}
+
+	p, ok := r.pending[rec.Partial.ID]
+	if !ok {
+		p = &dockerPending{firstSeen: time.Now(), stream: rec.Stream, time: rec.Time}
+		r.pending[rec.Partial.ID] = p
+	}
+	p.chunks = append(p.chunks, dockerChunk{Ordinal: rec.Partial.Ordinal, Log: rec.Log})
+
+	if !rec.Partial.Last {
+		return "", false
+	}
+
+	delete(r.pending, rec.Partial.ID)
+	return joinDockerChunks(p.chunks), true
+}
+
+// ExpireStale drops any partial-message groups that haven't seen their Last
+// chunk within the configured timeout, returning the best-effort
+// concatenation of whatever chunks arrived (along with the Stream/Time of
+// the group's first chunk) so a stalled sequence doesn't simply vanish.
+func (r *dockerReassembler) ExpireStale() []expiredDockerGroup {
+	var expired []expiredDockerGroup
+	now := time.Now()
+	for id, p := range r.pending {
+		if now.Sub(p.firstSeen) < r.timeout {
+			continue
+		}
+		expired = append(expired, expiredDockerGroup{
+			Text:   joinDockerChunks(p.chunks),
+			Stream: p.stream,
+			Time:   p.time,
+		})
+		delete(r.pending, id)
+	}
+	return expired
+}
+
+func joinDockerChunks(chunks []dockerChunk) string {
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Ordinal < chunks[j].Ordinal })
+	var b strings.Builder
+	for _, c := range chunks {
+		b.WriteString(c.Log)
+	}
+	return b.String()
+}
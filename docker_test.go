@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDockerReassemblerFeedNonPartial(t *testing.T) {
+	r := newDockerReassembler(time.Minute)
+	rec := &DockerJSONLogRecord{Log: "hello\n", Stream: "stdout", Time: "2026-07-29T12:00:00Z"}
+
+	msg, ok := r.Feed(rec)
+	if !ok || msg != "hello\n" {
+		t.Fatalf("Feed(non-partial) = (%q, %v); want (%q, true)", msg, ok, rec.Log)
+	}
+}
+
+func TestDockerReassemblerFeedReordersByOrdinal(t *testing.T) {
+	r := newDockerReassembler(time.Minute)
+	id := "chunk-id"
+
+	// Ordinal 1 arrives before Ordinal 0, simulating out-of-order delivery.
+	if _, ok := r.Feed(&DockerJSONLogRecord{Log: "world", Stream: "stdout", Partial: &PartialLogMetaData{ID: id, Ordinal: 1, Last: false}}); ok {
+		t.Fatalf("non-Last chunk reported complete")
+	}
+	msg, ok := r.Feed(&DockerJSONLogRecord{Log: "hello ", Stream: "stdout", Partial: &PartialLogMetaData{ID: id, Ordinal: 0, Last: true}})
+	if !ok {
+		t.Fatalf("Last chunk did not complete the record")
+	}
+	if msg != "hello world" {
+		t.Fatalf("reassembled message = %q; want %q", msg, "hello world")
+	}
+}
+
+func TestDockerReassemblerExpireStaleCarriesMetadata(t *testing.T) {
+	r := newDockerReassembler(time.Millisecond)
+	id := "stale-id"
+
+	if _, ok := r.Feed(&DockerJSONLogRecord{Log: "partial", Stream: "stderr", Time: "2026-07-29T12:00:00Z", Partial: &PartialLogMetaData{ID: id, Ordinal: 0, Last: false}}); ok {
+		t.Fatalf("non-Last chunk reported complete")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	expired := r.ExpireStale()
+	if len(expired) != 1 {
+		t.Fatalf("ExpireStale() returned %d groups; want 1", len(expired))
+	}
+	got := expired[0]
+	if got.Text != "partial" || got.Stream != "stderr" || got.Time != "2026-07-29T12:00:00Z" {
+		t.Fatalf("ExpireStale() = %+v; want Text=%q Stream=%q Time=%q", got, "partial", "stderr", "2026-07-29T12:00:00Z")
+	}
+
+	// the group should have been dropped, so it doesn't expire again.
+	if expired := r.ExpireStale(); len(expired) != 0 {
+		t.Fatalf("ExpireStale() returned %d groups after the first drain; want 0", len(expired))
+	}
+}
+
+func TestJoinDockerChunks(t *testing.T) {
+	chunks := []dockerChunk{
+		{Ordinal: 2, Log: "c"},
+		{Ordinal: 0, Log: "a"},
+		{Ordinal: 1, Log: "b"},
+	}
+	if got := joinDockerChunks(chunks); got != "abc" {
+		t.Fatalf("joinDockerChunks(...) = %q; want %q", got, "abc")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// logFormat identifies how a file's lines should be parsed. formatAuto
+// defers the decision to detectFormat, which samples the first few lines
+// read by tailFile.
+type logFormat string
+
+const (
+	formatAuto   logFormat = "auto"
+	formatDocker logFormat = "docker"
+	formatCRI    logFormat = "cri"
+	formatPlain  logFormat = "plain"
+)
+
+// formatDetectSampleLines bounds how many lines detectFormat is given to
+// make up its mind before falling back to plain text.
+const formatDetectSampleLines = 3
+
+// fileTarget is a single positional argument once its optional
+// ":docker|cri|plain|auto" suffix has been split off.
+type fileTarget struct {
+	Path   string
+	Format logFormat
+}
+
+// parseFileTarget splits a trailing ":docker|cri|plain|auto" suffix off of
+// a filename argument, e.g. "kube.log:cri", leaving format detection on
+// auto for plain filenames.
+func parseFileTarget(arg string) fileTarget {
+	if idx := strings.LastIndex(arg, ":"); idx != -1 {
+		switch logFormat(arg[idx+1:]) {
+		case formatDocker, formatCRI, formatPlain, formatAuto:
+			return fileTarget{Path: arg[:idx], Format: logFormat(arg[idx+1:])}
+		}
+	}
+	return fileTarget{Path: arg, Format: formatAuto}
+}
+
+// detectFormat inspects a single sample line and reports the format it
+// looks like: a successful Docker JSON unmarshal with a non-empty log
+// field wins first, then a parseable CRI prefix, else plain text.
+func detectFormat(sample string) logFormat {
+	r := &DockerJSONLogRecord{}
+	if err := json.Unmarshal([]byte(sample), r); err == nil && r.Log != "" {
+		return formatDocker
+	}
+	if _, err := parseCRILine(sample); err == nil {
+		return formatCRI
+	}
+	return formatPlain
+}
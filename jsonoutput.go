@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// outputJSON is the --output value that switches tailFile from colored,
+// filename-prefixed text to structured jsonRecord lines on stdout.
+const outputJSON = "json"
+
+// jsonRecord is the schema emitted by --output=json: one JSON object per
+// line, merging every tailed file into a single stdout stream that can be
+// piped into jq or another processor in place of the human-readable format.
+type jsonRecord struct {
+	File    string `json:"file"`
+	Ts      string `json:"ts,omitempty"`
+	Stream  string `json:"stream,omitempty"`
+	Message string `json:"message"`
+	Partial bool   `json:"partial"`
+}
+
+// emitJSON writes el as one jsonRecord line to stdout, guarded by
+// stdoutLock since every tailFile goroutine writes into the same merged
+// stream.
+func emitJSON(file string, el emittedLine, stdoutLock *sync.Mutex) {
+	b, err := json.Marshal(jsonRecord{
+		File:    file,
+		Ts:      el.Timestamp,
+		Stream:  el.Stream,
+		Message: el.Text,
+		Partial: el.Incomplete,
+	})
+	if err != nil {
+		fmt.Println("Error marshaling JSON output:\n", err)
+		return
+	}
+
+	stdoutLock.Lock()
+	fmt.Println(string(b))
+	stdoutLock.Unlock()
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"github.com/fatih/color"
@@ -19,15 +20,24 @@ import (
 
 var colors = []color.Attribute{color.FgGreen, color.FgCyan, color.FgYellow, color.FgBlue, color.FgRed, color.FgMagenta}
 
+// maxConsecutiveParseErrors bounds how many parse failures in a row an
+// auto-detected format is allowed before tailFile gives up on it and falls
+// back to plain text.
+const maxConsecutiveParseErrors = 3
+
 type DockerJSONLogRecord struct {
-	Log    string `json:"log"`
-	Stream string `json:"stream"`
-	Time   string `json:"time"`
+	Log     string              `json:"log"`
+	Stream  string              `json:"stream"`
+	Time    string              `json:"time"`
+	Partial *PartialLogMetaData `json:"partial_log_metadata,omitempty"`
 }
 
 type options struct {
-	Docker      bool `short:"d" long:"docker" description:"Parse log files as Docker JSON format"`
-	Positionals struct {
+	CRIMaxBufferBytes int    `long:"cri-max-buffer-bytes" default:"1048576" description:"Maximum bytes to buffer while reassembling a partial CRI log line before dropping it"`
+	Since             string `long:"since" description:"Replay gzip-rotated log segments (foo.log.1.gz, foo.log.2.gz, ...) and include history no older than this duration before live-tailing, e.g. 1h; lines without a parseable timestamp, such as plain text, are always included"`
+	MetricsAddr       string `long:"metrics-addr" description:"Address (e.g. :9090) to serve Prometheus /metrics on; disabled if unset"`
+	Output            string `long:"output" choice:"text" choice:"json" default:"text" description:"Output mode: text (colored, filename-prefixed) or json (structured, one object per line, merged across files)"`
+	Positionals       struct {
 		Filenames []string
 	} `positional-args:"yes" required:"yes"`
 }
@@ -61,20 +71,41 @@ func trimFilename(s string, max int) string {
 	return s
 }
 
-func parseRecord(record string) (line string, err error) {
-	line = record
-	if opts.Docker {
-		r := &DockerJSONLogRecord{}
-		err = json.Unmarshal([]byte(record), r)
-		if err != nil {
-			return line, errors.New("JSON Parse Error: " + err.Error())
-		}
-		line = strings.TrimRight(r.Log, "\n")
+// emittedLine is a fully reassembled record ready to print, carrying
+// whatever stream/timestamp metadata its format exposed so --output=json
+// can populate its "stream" and "ts" fields. Incomplete marks a record that
+// was forced out by ExpireStale before its terminating chunk ever arrived,
+// so --output=json can report it as "partial" instead of claiming a clean
+// reassembly.
+type emittedLine struct {
+	Text       string
+	Stream     string
+	Timestamp  string
+	Incomplete bool
+}
+
+// parseDockerRecord unmarshals a raw Docker JSON record. A record carrying
+// PartialLogMetaData is fed through reasm so that chunks split across
+// multiple JSON lines are reassembled before being printed; complete is
+// false while a partial record is still waiting on the rest of its chunks.
+func parseDockerRecord(record string, reasm *dockerReassembler) (el emittedLine, complete bool, err error) {
+	r := &DockerJSONLogRecord{}
+	if err := json.Unmarshal([]byte(record), r); err != nil {
+		return emittedLine{}, false, errors.New("JSON Parse Error: " + err.Error())
+	}
+
+	message, ok := reasm.Feed(r)
+	if !ok {
+		return emittedLine{}, false, nil
 	}
-	return line, nil
+	return emittedLine{
+		Text:      strings.TrimRight(message, "\n"),
+		Stream:    r.Stream,
+		Timestamp: r.Time,
+	}, true, nil
 }
 
-func tailFile(file string, c color.Attribute, termWidth int, stdoutLock *sync.Mutex, done chan bool) {
+func tailFile(target fileTarget, since time.Duration, c color.Attribute, termWidth int, stdoutLock *sync.Mutex, done chan bool) {
 	defer func() { done <- true }()
 	colorPrintf := color.New(c).PrintfFunc()
 
@@ -83,6 +114,7 @@ func tailFile(file string, c color.Attribute, termWidth int, stdoutLock *sync.Mu
 	// the prefix `|filename| `.
 	maxLineSize := int(termWidth - (17 + 4))
 
+	file := target.Path
 	config := tail.Config{
 		Follow:    true,
 		ReOpen:    true,
@@ -91,6 +123,9 @@ func tailFile(file string, c color.Attribute, termWidth int, stdoutLock *sync.Mu
 		Poll:      true,
 		Logger:    tail.DiscardingLogger,
 	}
+	if metricsEnabled {
+		config.Logger = &metricsLogger{file: file}
+	}
 	// if no file specified, assume stdin
 	if file == "" {
 		config.Location = nil
@@ -105,21 +140,118 @@ func tailFile(file string, c color.Attribute, termWidth int, stdoutLock *sync.Mu
 	}
 
 	// throw away the first "line" as it is likely a partial line due to the seeking function of the tail
-	// library being byte specific and not line aware. A partial line would fail json parsing when -d is used so
+	// library being byte specific and not line aware. A partial line would fail format detection/parsing so
 	// it's best to skip it.
 	<-t.Lines
 
-	for record := range t.Lines {
-		text, err := parseRecord(record.Text)
-		if err != nil {
-			fmt.Println("Error parsing line:\n", err)
-			continue
+	var rotatedSegments []string
+	if since > 0 {
+		rotatedSegments = findRotatedSegments(file)
+	}
+
+	format := target.Format
+	var sampled []*tail.Line
+	if format == formatAuto {
+		format = formatPlain
+		detected := false
+		if len(rotatedSegments) > 0 {
+			if sample, ok := peekFirstLine(rotatedSegments[0]); ok {
+				if f := detectFormat(sample); f != formatPlain {
+					format = f
+					detected = true
+				}
+			}
+		}
+		if !detected {
+			for i := 0; i < formatDetectSampleLines; i++ {
+				record, ok := <-t.Lines
+				if !ok {
+					break
+				}
+				sampled = append(sampled, record)
+				if f := detectFormat(record.Text); f != formatPlain {
+					format = f
+					break
+				}
+			}
+		}
+	}
+
+	// If the format was auto-detected, a run of consecutive parse failures means the
+	// detection sample was unrepresentative (e.g. a rotated segment that looked like
+	// CRI while the live file is actually plain text) rather than truly corrupt input,
+	// so fall back to plain text instead of erroring on every subsequent line forever.
+	autoDetected := target.Format == formatAuto
+	consecutiveParseErrors := 0
+	noteParseResult := func(err error) {
+		if err == nil {
+			consecutiveParseErrors = 0
+			return
+		}
+		consecutiveParseErrors++
+		if autoDetected && format != formatPlain && consecutiveParseErrors >= maxConsecutiveParseErrors {
+			fmt.Printf("Warning: %d consecutive parse errors for %q, falling back to plain text\n", consecutiveParseErrors, file)
+			format = formatPlain
+			consecutiveParseErrors = 0
+		}
+	}
+
+	var criBuf *criReassembler
+	if format == formatCRI {
+		criBuf = newCRIReassembler(opts.CRIMaxBufferBytes)
+		if metricsEnabled {
+			criBuf.onDrop = func() { droppedOversizeTotal.WithLabelValues(file).Inc() }
+		}
+	}
+
+	var dockerReasm *dockerReassembler
+	var expireChan <-chan time.Time
+	if format == formatDocker {
+		dockerReasm = newDockerReassembler(0)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		expireChan = ticker.C
+	}
+
+	var metricsChan <-chan time.Time
+	if metricsEnabled {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		metricsChan = ticker.C
+	}
+
+	// processRecord dispatches a raw line to the parser for the file's detected/declared
+	// format, returning the line to print and whether it is complete (false while a
+	// partial CRI/Docker record is still waiting on the rest of its chunks).
+	processRecord := func(raw string) (el emittedLine, complete bool, err error) {
+		switch format {
+		case formatCRI:
+			l, err := parseCRILine(raw)
+			if err != nil {
+				return emittedLine{}, false, err
+			}
+			message, ok := criBuf.Feed(l)
+			if !ok {
+				return emittedLine{}, false, nil
+			}
+			return emittedLine{Text: message, Stream: l.Stream, Timestamp: l.Timestamp}, true, nil
+		case formatDocker:
+			return parseDockerRecord(raw, dockerReasm)
+		default:
+			return emittedLine{Text: raw}, true, nil
+		}
+	}
+
+	emit := func(el emittedLine) {
+		if opts.Output == outputJSON {
+			emitJSON(file, el, stdoutLock)
+			return
 		}
 
 		// split long strings into multiple lines to preserve formatting of the left-hand-side
-		lines := []string{text}
-		if len(text) > maxLineSize {
-			lines = util.PartitionString(text, maxLineSize)
+		lines := []string{el.Text}
+		if len(el.Text) > maxLineSize {
+			lines = util.PartitionString(el.Text, maxLineSize)
 		}
 
 		stdoutLock.Lock()
@@ -128,6 +260,84 @@ func tailFile(file string, c color.Attribute, termWidth int, stdoutLock *sync.Mu
 		}
 		stdoutLock.Unlock()
 	}
+
+	if len(rotatedSegments) > 0 {
+		cutoff := time.Now().Add(-since)
+		for _, seg := range rotatedSegments {
+			err := streamGzipSegment(seg, func(raw string) {
+				if ts, ok := lineTimestamp(raw, format); ok && ts.Before(cutoff) {
+					return
+				}
+				el, complete, err := processRecord(raw)
+				observeLine(file, raw, format, err)
+				noteParseResult(err)
+				if err != nil {
+					fmt.Println("Error parsing line:\n", err)
+					return
+				}
+				if !complete {
+					return
+				}
+				emit(el)
+			})
+			if err != nil {
+				fmt.Printf("Error reading rotated segment %s: %s\n", seg, err)
+			}
+		}
+	}
+
+	for _, record := range sampled {
+		el, complete, err := processRecord(record.Text)
+		observeLine(file, record.Text, format, err)
+		noteParseResult(err)
+		if err != nil {
+			fmt.Println("Error parsing line:\n", err)
+			continue
+		}
+		if !complete {
+			continue
+		}
+		emit(el)
+	}
+
+readLoop:
+	for {
+		select {
+		case record, ok := <-t.Lines:
+			if !ok {
+				break readLoop
+			}
+
+			el, complete, err := processRecord(record.Text)
+			observeLine(file, record.Text, format, err)
+			noteParseResult(err)
+			if err != nil {
+				fmt.Println("Error parsing line:\n", err)
+				continue
+			}
+			if !complete {
+				continue
+			}
+			emit(el)
+
+		case <-expireChan:
+			for _, g := range dockerReasm.ExpireStale() {
+				emit(emittedLine{Text: strings.TrimRight(g.Text, "\n"), Stream: g.Stream, Timestamp: g.Time, Incomplete: true})
+			}
+
+		case <-metricsChan:
+			if pos, err := t.Tell(); err == nil {
+				if fi, err := os.Stat(file); err == nil {
+					lag := fi.Size() - pos
+					if lag < 0 {
+						lag = 0
+					}
+					tailLagBytes.WithLabelValues(file).Set(float64(lag))
+				}
+			}
+		}
+	}
+
 	err = t.Wait()
 	if err != nil {
 		fmt.Println(err)
@@ -145,12 +355,25 @@ func main() {
 		log.Fatal("Unable to determine terminal width: ", err)
 	}
 
+	var since time.Duration
+	if opts.Since != "" {
+		since, err = time.ParseDuration(opts.Since)
+		if err != nil {
+			log.Fatal("Invalid --since duration: ", err)
+		}
+	}
+
+	if opts.MetricsAddr != "" {
+		metricsEnabled = true
+		serveMetrics(opts.MetricsAddr)
+	}
+
 	done := make(chan bool)
 	var stdoutLock = &sync.Mutex{}
 
 	for idx, filename := range opts.Positionals.Filenames {
 		c := colors[idx%len(colors)]
-		go tailFile(filename, c, width, stdoutLock, done)
+		go tailFile(parseFileTarget(filename), since, c, width, stdoutLock, done)
 	}
 	for _, _ = range opts.Positionals.Filenames {
 		<-done
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// findRotatedSegments returns the gzip-compressed rotated siblings of base
+// (e.g. "foo.log.2.gz", "foo.log.1.gz" for base "foo.log"), ordered oldest
+// first so they can be replayed in chronological order ahead of the live
+// file. It only runs once per tailFile call, before the live file is handed
+// to tail.TailFile; a rotation that happens mid-tail is not re-scanned by
+// this function but is instead the responsibility of hpcloud/tail's own
+// ReOpen/Poll handling, which follows the rename and reopens the fd under
+// the original path rather than the new .gz sibling.
+func findRotatedSegments(base string) []string {
+	matches, err := filepath.Glob(base + ".*.gz")
+	if err != nil {
+		return nil
+	}
+
+	type segment struct {
+		path  string
+		index int
+	}
+	prefix := filepath.Base(base) + "."
+	segments := make([]segment, 0, len(matches))
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), prefix), ".gz")
+		n, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{path: m, index: n})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].index > segments[j].index })
+
+	paths := make([]string, len(segments))
+	for i, s := range segments {
+		paths[i] = s.path
+	}
+	return paths
+}
+
+// streamGzipSegment decompresses path and invokes onLine for each line in
+// order, letting the caller feed rotated history through the same
+// format-detection/reassembly pipeline used for the live file.
+func streamGzipSegment(path string, onLine func(string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("gzip %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onLine(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// peekFirstLine returns the first line of a gzip-compressed segment, used
+// for format auto-detection without having to buffer the whole segment.
+func peekFirstLine(path string) (string, bool) {
+	var first string
+	found := false
+	err := streamGzipSegment(path, func(line string) {
+		if found {
+			return
+		}
+		first = line
+		found = true
+	})
+	if err != nil || !found {
+		return "", false
+	}
+	return first, true
+}
+
+// lineTimestamp extracts a line's timestamp according to its log format, so
+// --since can filter rotated history. Plain text carries no timestamp and
+// is always kept.
+func lineTimestamp(raw string, format logFormat) (time.Time, bool) {
+	switch format {
+	case formatCRI:
+		l, err := parseCRILine(raw)
+		if err != nil {
+			return time.Time{}, false
+		}
+		ts, err := time.Parse(time.RFC3339Nano, l.Timestamp)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return ts, true
+	case formatDocker:
+		r := &DockerJSONLogRecord{}
+		if err := json.Unmarshal([]byte(raw), r); err != nil {
+			return time.Time{}, false
+		}
+		ts, err := time.Parse(time.RFC3339Nano, r.Time)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return ts, true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeGzipFile(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, line := range lines {
+		if _, err := gz.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("writing gzip content: %v", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("closing gzip writer: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestFindRotatedSegmentsOrdersOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "foo.log")
+
+	writeGzipFile(t, base+".1.gz", "newer")
+	writeGzipFile(t, base+".2.gz", "older")
+	// a sibling that doesn't match the "<base>.N.gz" pattern should be ignored.
+	writeGzipFile(t, base+".bak.gz", "not a rotation index")
+
+	got := findRotatedSegments(base)
+	want := []string{base + ".2.gz", base + ".1.gz"}
+	if len(got) != len(want) {
+		t.Fatalf("findRotatedSegments(%q) = %v; want %v", base, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("findRotatedSegments(%q)[%d] = %q; want %q", base, i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindRotatedSegmentsNoSiblings(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "foo.log")
+
+	if got := findRotatedSegments(base); len(got) != 0 {
+		t.Fatalf("findRotatedSegments(%q) = %v; want empty", base, got)
+	}
+}
+
+func TestStreamGzipSegment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.log.1.gz")
+	writeGzipFile(t, path, "one", "two", "three")
+
+	var got []string
+	if err := streamGzipSegment(path, func(line string) { got = append(got, line) }); err != nil {
+		t.Fatalf("streamGzipSegment(%q) returned error: %v", path, err)
+	}
+
+	want := []string{"one", "two", "three"}
+	if len(got) != len(want) {
+		t.Fatalf("streamGzipSegment(%q) produced %v; want %v", path, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("streamGzipSegment(%q)[%d] = %q; want %q", path, i, got[i], want[i])
+		}
+	}
+}
+
+func TestLineTimestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		format logFormat
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "cri",
+			raw:    "2026-07-29T12:00:00.000000000Z stdout F hello",
+			format: formatCRI,
+			want:   "2026-07-29T12:00:00.000000000Z",
+			wantOK: true,
+		},
+		{
+			name:   "docker",
+			raw:    `{"log":"hello\n","stream":"stdout","time":"2026-07-29T12:00:00.000000000Z"}`,
+			format: formatDocker,
+			want:   "2026-07-29T12:00:00.000000000Z",
+			wantOK: true,
+		},
+		{
+			name:   "plain has no timestamp",
+			raw:    "just some text",
+			format: formatPlain,
+			wantOK: false,
+		},
+		{
+			name:   "malformed cri",
+			raw:    "not a cri line",
+			format: formatCRI,
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := lineTimestamp(tc.raw, tc.format)
+			if ok != tc.wantOK {
+				t.Fatalf("lineTimestamp(%q, %v) ok = %v; want %v", tc.raw, tc.format, ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			want, err := time.Parse(time.RFC3339Nano, tc.want)
+			if err != nil {
+				t.Fatalf("invalid test fixture timestamp %q: %v", tc.want, err)
+			}
+			if !got.Equal(want) {
+				t.Fatalf("lineTimestamp(%q, %v) = %v; want %v", tc.raw, tc.format, got, want)
+			}
+		})
+	}
+}